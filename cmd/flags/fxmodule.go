@@ -0,0 +1,109 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+	pMetrics "github.com/jaegertracing/jaeger/pkg/metrics"
+)
+
+// AdminPort carries the port the admin server should listen on. Callers
+// supply it via fx.Supply(flags.AdminPort(port)).
+type AdminPort int
+
+// Module provides *zap.Logger, metrics.Factory, *AdminServer and
+// *healthcheck.HealthCheck from a *viper.Viper, and registers the OnStart/
+// OnStop hooks that used to live in Service.Start and Service.RunAndThen.
+var Module = fx.Options(
+	fx.Provide(
+		newFxLogger,
+		newFxMetricsBuilder,
+		newFxMetricsFactory,
+		newFxAdminServer,
+		newFxHealthCheck,
+	),
+	fx.Invoke(registerFxLifecycle),
+)
+
+func newFxLogger(v *viper.Viper) (*zap.Logger, error) {
+	sFlags := new(SharedFlags).InitFromViper(v)
+	newProdConfig := zap.NewProductionConfig()
+	newProdConfig.Sampling = nil
+	logger, err := sFlags.NewLogger(newProdConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create logger")
+	}
+	return logger, nil
+}
+
+// newFxMetricsBuilder is provided on its own so the same *pMetrics.Builder
+// instance backs both the metrics.Factory and the /metrics handler mounted
+// in registerFxLifecycle.
+func newFxMetricsBuilder(v *viper.Viper) *pMetrics.Builder {
+	return new(pMetrics.Builder).InitFromViper(v)
+}
+
+func newFxMetricsFactory(mb *pMetrics.Builder) (metrics.Factory, error) {
+	factory, err := mb.CreateMetricsFactory("")
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create metrics factory")
+	}
+	return factory, nil
+}
+
+func newFxAdminServer(v *viper.Viper, port AdminPort, logger *zap.Logger) *AdminServer {
+	admin := NewAdminServer(int(port))
+	admin.initFromViper(v, logger)
+	return admin
+}
+
+func newFxHealthCheck(admin *AdminServer) *healthcheck.HealthCheck {
+	return admin.HC()
+}
+
+// registerFxLifecycle takes metrics.Factory, unused here, so that fx creates
+// the factory first: mb.Handler() is only set once the factory exists.
+func registerFxLifecycle(lc fx.Lifecycle, mb *pMetrics.Builder, _ metrics.Factory, admin *AdminServer, hc *healthcheck.HealthCheck, logger *zap.Logger) {
+	if h := mb.Handler(); h != nil {
+		route := mb.HTTPRoute
+		logger.Info("Mounting metrics handler on admin server", zap.String("route", route))
+		admin.Handle(route, h)
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			// Readiness is set by RunAndThen, once the binary has finished
+			// wiring up its actual collector/query/agent components; not
+			// here, where only the admin server itself is up.
+			if err := admin.Serve(); err != nil {
+				return errors.Wrap(err, "cannot start the admin server")
+			}
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			hc.Set(healthcheck.Unavailable)
+			admin.Close()
+			return logger.Sync()
+		},
+	})
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+
+	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+)
+
+// TestModuleDoesNotReportReadyOnStart guards against Module's OnStart
+// reporting Ready as soon as the admin server is listening: readiness must
+// stay HC's job alone, set by RunAndThen once the binary has finished
+// wiring up its actual collector/query/agent components.
+func TestModuleDoesNotReportReadyOnStart(t *testing.T) {
+	v := viper.New()
+
+	var admin *AdminServer
+	app := fx.New(
+		fx.Supply(v, AdminPort(0)),
+		Module,
+		fx.Populate(&admin),
+		fx.NopLogger,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, app.Start(ctx))
+	defer app.Stop(ctx)
+
+	assert.Equal(t, healthcheck.Unavailable, admin.HC().Get())
+}
@@ -15,15 +15,20 @@
 package flags
 
 import (
+	"context"
 	"flag"
 	"io/ioutil"
 	"os"
 	"os/signal"
+	"sort"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/fx"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/grpclog"
 
@@ -31,6 +36,25 @@ import (
 	pMetrics "github.com/jaegertracing/jaeger/pkg/metrics"
 )
 
+// fxStartTimeout bounds how long the fx app backing Service is given to start or stop.
+const fxStartTimeout = 5 * time.Second
+
+const (
+	shutdownDrainWaitFlag = "admin.shutdown-drain-wait"
+	shutdownTimeoutFlag   = "admin.shutdown-timeout"
+
+	defaultShutdownDrainWait = 15 * time.Second
+	defaultShutdownTimeout   = 5 * time.Second
+)
+
+// shutdownHook is a named, prioritized callback registered via
+// Service.AddShutdownHook.
+type shutdownHook struct {
+	name     string
+	priority int
+	fn       func(ctx context.Context) error
+}
+
 // Service represents an abstract Jaeger backend component with some basic shared functionality.
 type Service struct {
 	// AdminPort is the HTTP port number for admin server.
@@ -51,20 +75,42 @@ type Service struct {
 	signalsChannel chan os.Signal
 
 	hcStatusChannel chan healthcheck.Status
+
+	// app is the fx.App backing Start/RunAndThen.
+	app *fx.App
+
+	checksOnce   sync.Once
+	checksCtx    context.Context
+	checksCancel context.CancelFunc
+	checks       *healthcheck.Registry
+
+	// shutdownDrainWait is how long HC stays Unavailable before shutdown hooks run.
+	shutdownDrainWait time.Duration
+
+	// shutdownTimeout bounds each individual shutdown hook invocation.
+	shutdownTimeout time.Duration
+
+	shutdownMu    sync.Mutex
+	shutdownHooks []shutdownHook
+
+	// reloadHook, if set via SetReloadHook, runs on SIGHUP.
+	reloadHook func() error
 }
 
 // NewService creates a new Service.
 func NewService(adminPort int) *Service {
 	signalsChannel := make(chan os.Signal, 1)
 	hcStatusChannel := make(chan healthcheck.Status)
-	signal.Notify(signalsChannel, os.Interrupt, syscall.SIGTERM)
+	signal.Notify(signalsChannel, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 
 	grpclog.SetLoggerV2(grpclog.NewLoggerV2(ioutil.Discard, os.Stderr, os.Stderr))
 
 	return &Service{
-		Admin:           NewAdminServer(adminPort),
-		signalsChannel:  signalsChannel,
-		hcStatusChannel: hcStatusChannel,
+		Admin:             NewAdminServer(adminPort),
+		signalsChannel:    signalsChannel,
+		hcStatusChannel:   hcStatusChannel,
+		shutdownDrainWait: defaultShutdownDrainWait,
+		shutdownTimeout:   defaultShutdownTimeout,
 	}
 }
 
@@ -78,6 +124,15 @@ func (s *Service) AddFlags(flagSet *flag.FlagSet) {
 	}
 	pMetrics.AddFlags(flagSet)
 	s.Admin.AddFlags(flagSet)
+
+	flagSet.Duration(
+		shutdownDrainWaitFlag,
+		defaultShutdownDrainWait,
+		"How long to keep the admin and data ports open with the health check reporting Unavailable before running shutdown hooks, so load balancers can stop sending traffic")
+	flagSet.Duration(
+		shutdownTimeoutFlag,
+		defaultShutdownTimeout,
+		"How long to wait for each registered shutdown hook to complete before moving on to the next one")
 }
 
 // SetHealthCheckStatus sets status of healthcheck
@@ -85,37 +140,28 @@ func (s *Service) SetHealthCheckStatus(status healthcheck.Status) {
 	s.hcStatusChannel <- healthcheck.Unavailable
 }
 
-// Start bootstraps the service and starts the admin server.
+// Start bootstraps the service and starts the admin server, via an fx.App
+// built from Module.
 func (s *Service) Start(v *viper.Viper) error {
 	if err := TryLoadConfigFile(v); err != nil {
 		return errors.Wrap(err, "cannot load config file")
 	}
 
-	sFlags := new(SharedFlags).InitFromViper(v)
-	newProdConfig := zap.NewProductionConfig()
-	newProdConfig.Sampling = nil
-	if logger, err := sFlags.NewLogger(newProdConfig); err == nil {
-		s.Logger = logger
-	} else {
-		return errors.Wrap(err, "cannot create logger")
-	}
+	s.app = fx.New(
+		fx.Supply(v, AdminPort(s.AdminPort)),
+		Module,
+		fx.Populate(&s.Logger, &s.MetricsFactory, &s.Admin),
+		fx.NopLogger,
+	)
 
-	metricsBuilder := new(pMetrics.Builder).InitFromViper(v)
-	metricsFactory, err := metricsBuilder.CreateMetricsFactory("")
-	if err != nil {
-		return errors.Wrap(err, "cannot create metrics factory")
+	ctx, cancel := context.WithTimeout(context.Background(), fxStartTimeout)
+	defer cancel()
+	if err := s.app.Start(ctx); err != nil {
+		return errors.Wrap(err, "cannot start the service")
 	}
-	s.MetricsFactory = metricsFactory
 
-	s.Admin.initFromViper(v, s.Logger)
-	if h := metricsBuilder.Handler(); h != nil {
-		route := metricsBuilder.HTTPRoute
-		s.Logger.Info("Mounting metrics handler on admin server", zap.String("route", route))
-		s.Admin.Handle(route, h)
-	}
-	if err := s.Admin.Serve(); err != nil {
-		return errors.Wrap(err, "cannot start the admin server")
-	}
+	s.shutdownDrainWait = v.GetDuration(shutdownDrainWaitFlag)
+	s.shutdownTimeout = v.GetDuration(shutdownTimeoutFlag)
 
 	return nil
 }
@@ -125,8 +171,35 @@ func (s *Service) HC() *healthcheck.HealthCheck {
 	return s.Admin.HC()
 }
 
-// RunAndThen sets the health check to Ready and blocks until SIGTERM is received.
-// If then runs the shutdown function and exits.
+// RegisterCheck registers a named, periodically evaluated sub-check that
+// feeds into HC()'s aggregate status; results are served as JSON at
+// "/health" on the admin server.
+func (s *Service) RegisterCheck(name string, probe healthcheck.Probe, opts healthcheck.CheckOptions) {
+	s.checksOnce.Do(func() {
+		s.checksCtx, s.checksCancel = context.WithCancel(context.Background())
+		s.checks = healthcheck.NewRegistry(s.hcStatusChannel, s.Logger)
+		s.Admin.Handle("/health", s.checks.Handler())
+	})
+	s.checks.Register(s.checksCtx, name, probe, opts, s.MetricsFactory)
+}
+
+// AddShutdownHook registers fn to run during shutdown, in ascending
+// priority order, each bounded by --admin.shutdown-timeout.
+func (s *Service) AddShutdownHook(name string, priority int, fn func(ctx context.Context) error) {
+	s.shutdownMu.Lock()
+	defer s.shutdownMu.Unlock()
+	s.shutdownHooks = append(s.shutdownHooks, shutdownHook{name: name, priority: priority, fn: fn})
+}
+
+// SetReloadHook registers fn to run on SIGHUP instead of terminating.
+func (s *Service) SetReloadHook(fn func() error) {
+	s.reloadHook = fn
+}
+
+// RunAndThen sets the health check to Ready and blocks until a termination
+// signal is received, then runs the ordered shutdown pipeline and exits.
+// SIGHUP is handled separately: it invokes the reload hook set via
+// SetReloadHook, if any, and does not terminate the process.
 func (s *Service) RunAndThen(shutdown func()) {
 	s.HC().Ready()
 
@@ -135,18 +208,108 @@ statusLoop:
 		select {
 		case status := <-s.hcStatusChannel:
 			s.HC().Set(status)
-		case <-s.signalsChannel:
+		case sig := <-s.signalsChannel:
+			if sig == syscall.SIGHUP {
+				s.reload()
+				continue
+			}
 			break statusLoop
 		}
 	}
 
+	s.shutdown(shutdown)
+}
+
+// reload invokes the hook registered via SetReloadHook in response to
+// SIGHUP. Errors are logged but do not terminate the process.
+func (s *Service) reload() {
+	if s.reloadHook == nil {
+		return
+	}
+	s.Logger.Info("Reloading on SIGHUP")
+	if err := s.reloadHook(); err != nil {
+		s.Logger.Error("Reload failed", zap.Error(err))
+	}
+}
+
+// shutdown drains, runs shutdown hooks, then closes the admin server,
+// recording each phase's duration.
+func (s *Service) shutdown(legacyShutdown func()) {
+	start := time.Now()
 	s.Logger.Info("Shutting down")
+
+	// Stop and cancel the registered checks before announcing Unavailable:
+	// otherwise a check that was unhealthy at shutdown time but recovers
+	// mid-drain could push HC back to Ready while the service is supposed
+	// to be draining.
+	if s.checks != nil {
+		s.checks.Stop()
+	}
+	if s.checksCancel != nil {
+		s.checksCancel()
+	}
+
 	s.HC().Set(healthcheck.Unavailable)
 
-	if shutdown != nil {
-		shutdown()
+	s.recordShutdownPhase("drain", func() {
+		time.Sleep(s.shutdownDrainWait)
+	})
+
+	if legacyShutdown != nil {
+		s.recordShutdownPhase("legacy", legacyShutdown)
+	}
+
+	s.recordShutdownPhase("hooks", s.runShutdownHooks)
+
+	s.recordShutdownPhase("admin", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), fxStartTimeout)
+		defer cancel()
+		if err := s.app.Stop(ctx); err != nil {
+			s.Logger.Error("Error during shutdown", zap.Error(err))
+		}
+	})
+
+	s.Logger.Info("Shutdown complete", zap.Duration("total", time.Since(start)))
+}
+
+// recordShutdownPhase runs fn, then logs and records its duration as a
+// jaeger_shutdown_duration_seconds{phase=...} histogram.
+func (s *Service) recordShutdownPhase(phase string, fn func()) {
+	start := time.Now()
+	fn()
+	elapsed := time.Since(start)
+
+	s.Logger.Info("Shutdown phase complete", zap.String("phase", phase), zap.Duration("elapsed", elapsed))
+	if s.MetricsFactory != nil {
+		s.MetricsFactory.Histogram(metrics.HistogramOptions{
+			Name: "jaeger_shutdown_duration_seconds",
+			Tags: map[string]string{"phase": phase},
+		}).Record(elapsed.Seconds())
 	}
+}
+
+// runShutdownHooks invokes the registered shutdown hooks in ascending
+// priority order, each with its own shutdownTimeout deadline. A hook's
+// error is logged but does not prevent the remaining hooks from running.
+func (s *Service) runShutdownHooks() {
+	s.shutdownMu.Lock()
+	hooks := append([]shutdownHook(nil), s.shutdownHooks...)
+	s.shutdownMu.Unlock()
+
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].priority < hooks[j].priority })
 
-	s.Admin.Close()
-	s.Logger.Info("Shutdown complete")
+	for _, h := range hooks {
+		ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+		start := time.Now()
+		err := h.fn(ctx)
+		cancel()
+
+		if err != nil {
+			s.Logger.Error("Shutdown hook failed",
+				zap.String("hook", h.name), zap.Duration("elapsed", time.Since(start)), zap.Error(err))
+			continue
+		}
+		s.Logger.Info("Shutdown hook complete",
+			zap.String("hook", h.name), zap.Duration("elapsed", time.Since(start)))
+	}
 }
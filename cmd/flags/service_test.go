@@ -0,0 +1,77 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newTestService() *Service {
+	return &Service{Logger: zap.NewNop(), shutdownTimeout: time.Second}
+}
+
+func TestRunShutdownHooksOrder(t *testing.T) {
+	s := newTestService()
+
+	var order []string
+	s.AddShutdownHook("third", 30, func(ctx context.Context) error {
+		order = append(order, "third")
+		return nil
+	})
+	s.AddShutdownHook("first", 10, func(ctx context.Context) error {
+		order = append(order, "first")
+		return nil
+	})
+	s.AddShutdownHook("second", 20, func(ctx context.Context) error {
+		order = append(order, "second")
+		return nil
+	})
+
+	s.runShutdownHooks()
+
+	assert.Equal(t, []string{"first", "second", "third"}, order)
+}
+
+func TestRunShutdownHooksContinuesAfterError(t *testing.T) {
+	s := newTestService()
+
+	var ran []string
+	s.AddShutdownHook("failing", 1, func(ctx context.Context) error {
+		ran = append(ran, "failing")
+		return assert.AnError
+	})
+	s.AddShutdownHook("following", 2, func(ctx context.Context) error {
+		ran = append(ran, "following")
+		return nil
+	})
+
+	s.runShutdownHooks()
+
+	assert.Equal(t, []string{"failing", "following"}, ran)
+}
+
+func TestRecordShutdownPhaseRunsFn(t *testing.T) {
+	s := newTestService()
+
+	var ran bool
+	s.recordShutdownPhase("drain", func() { ran = true })
+
+	assert.True(t, ran, "recordShutdownPhase must invoke fn even without a MetricsFactory")
+}
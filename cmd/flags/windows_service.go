@@ -0,0 +1,256 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build windows
+
+package flags
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// windowsServiceName is the name under which Jaeger components register
+// themselves with the Windows service control manager. A binary can override
+// it via SetWindowsServiceName before calling RunAsWindowsService.
+var windowsServiceName = "jaeger"
+
+// SetWindowsServiceName overrides the name used to install/run the process
+// as a Windows service. It must be called before RunAsWindowsService.
+func (s *Service) SetWindowsServiceName(name string) {
+	windowsServiceName = name
+}
+
+// windowsEventLogCore mirrors zap log records into the Windows event log so
+// operators can inspect them with the standard Event Viewer, in addition to
+// whatever sinks the logger was configured with.
+type windowsEventLogCore struct {
+	zapcore.LevelEnabler
+	log *eventlog.Log
+}
+
+func (c *windowsEventLogCore) With(fields []zapcore.Field) zapcore.Core { return c }
+
+func (c *windowsEventLogCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.LevelEnabler.Enabled(e.Level) {
+		return ce.AddCore(e, c)
+	}
+	return ce
+}
+
+func (c *windowsEventLogCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	msg := e.Message
+	switch {
+	case e.Level >= zapcore.ErrorLevel:
+		return c.log.Error(1, msg)
+	case e.Level >= zapcore.WarnLevel:
+		return c.log.Warning(1, msg)
+	default:
+		return c.log.Info(1, msg)
+	}
+}
+
+func (c *windowsEventLogCore) Sync() error { return nil }
+
+// windowsServiceHandler adapts a Service to the svc.Handler interface,
+// translating service control requests into the existing
+// signalsChannel/hcStatusChannel pattern used by RunAndThen.
+type windowsServiceHandler struct {
+	service  *Service
+	shutdown func()
+}
+
+// Execute implements svc.Handler. It reports StartPending/Running while the
+// service is up, and drives the same shutdown path as RunAndThen when the
+// service control manager asks it to stop.
+func (h *windowsServiceHandler) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (svcSpecificEC bool, exitCode uint32) {
+	const accepted = svc.AcceptStop | svc.AcceptShutdown
+
+	changes <- svc.Status{State: svc.StartPending}
+	h.service.HC().Ready()
+	changes <- svc.Status{State: svc.Running, Accepts: accepted}
+
+loop:
+	for {
+		select {
+		case status := <-h.service.hcStatusChannel:
+			h.service.HC().Set(status)
+		case req := <-r:
+			switch req.Cmd {
+			case svc.Interrogate:
+				changes <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				break loop
+			}
+		}
+	}
+
+	changes <- svc.Status{State: svc.StopPending}
+	h.service.Logger.Info("Shutting down Windows service")
+	h.service.shutdown(h.shutdown)
+	changes <- svc.Status{State: svc.Stopped}
+	return false, 0
+}
+
+// RunAsWindowsService runs the service under the Windows service control
+// manager, forwarding the same shutdown callback RunAndThen would use. When
+// the process is not running as a Windows service (e.g. started from an
+// interactive console), it transparently falls back to RunAndThen.
+func (s *Service) RunAsWindowsService(shutdown func()) error {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return errors.Wrap(err, "cannot determine if running as a Windows service")
+	}
+	if !isService {
+		s.RunAndThen(shutdown)
+		return nil
+	}
+
+	elog, err := eventlog.Open(windowsServiceName)
+	if err == nil {
+		s.Logger = s.Logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, &windowsEventLogCore{LevelEnabler: zapcore.InfoLevel, log: elog})
+		}))
+		defer elog.Close()
+	} else {
+		s.Logger.Warn("Cannot open Windows event log, service logs will not be mirrored there", zap.Error(err))
+	}
+
+	handler := &windowsServiceHandler{service: s, shutdown: shutdown}
+	return svc.Run(windowsServiceName, handler)
+}
+
+// InstallWindowsService registers the current executable as a Windows
+// service with the given name, passing through args on every start.
+func InstallWindowsService(name, displayName, description string, args ...string) error {
+	exePath, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "cannot resolve executable path")
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return errors.Wrap(err, "cannot connect to Windows service manager")
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(name); err == nil {
+		s.Close()
+		return fmt.Errorf("service %s already exists", name)
+	}
+
+	s, err := m.CreateService(name, exePath, mgr.Config{
+		DisplayName: displayName,
+		Description: description,
+		StartType:   mgr.StartAutomatic,
+	}, args...)
+	if err != nil {
+		return errors.Wrap(err, "cannot create Windows service")
+	}
+	defer s.Close()
+
+	if err := eventlog.InstallAsEventCreate(name, eventlog.Info|eventlog.Warning|eventlog.Error); err != nil {
+		return errors.Wrap(err, "cannot install event log source")
+	}
+	return nil
+}
+
+// UninstallWindowsService removes the named Windows service and its event
+// log registration.
+func UninstallWindowsService(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return errors.Wrap(err, "cannot connect to Windows service manager")
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return errors.Wrap(err, "service is not installed")
+	}
+	defer s.Close()
+
+	if err := s.Delete(); err != nil {
+		return errors.Wrap(err, "cannot delete Windows service")
+	}
+	if err := eventlog.Remove(name); err != nil {
+		return errors.Wrap(err, "cannot remove event log source")
+	}
+	return nil
+}
+
+// StartWindowsService starts the named, already-installed Windows service.
+func StartWindowsService(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return errors.Wrap(err, "cannot connect to Windows service manager")
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return errors.Wrap(err, "service is not installed")
+	}
+	defer s.Close()
+
+	return s.Start()
+}
+
+// StopWindowsService sends a stop control request to the named Windows
+// service and returns without waiting for it to reach the Stopped state.
+func StopWindowsService(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return errors.Wrap(err, "cannot connect to Windows service manager")
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return errors.Wrap(err, "service is not installed")
+	}
+	defer s.Close()
+
+	_, err = s.Control(svc.Stop)
+	return err
+}
+
+// HandleWindowsServiceCommand inspects args (typically os.Args[1:]) for the
+// "install", "uninstall", "start" or "stop" subcommand and, if found, carries
+// it out and returns true, so callers can exit immediately afterwards. If the
+// subcommand fails, the error is printed to stderr and the process exits with
+// status 1:
+//
+//	if flags.HandleWindowsServiceCommand("jaeger-collector", "Jaeger Collector", "...", os.Args[1:]) {
+//		return
+//	}
+//
+// This mirrors how the OTel Collector exposes the same subcommands. Any
+// remaining args after "install" are passed through to the installed
+// service's start command line.
+func HandleWindowsServiceCommand(name, displayName, description string, args []string) bool {
+	return windowsServiceCommand{
+		install:   InstallWindowsService,
+		uninstall: UninstallWindowsService,
+		start:     StartWindowsService,
+		stop:      StopWindowsService,
+	}.dispatch(name, displayName, description, args)
+}
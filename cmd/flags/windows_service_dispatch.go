@@ -0,0 +1,63 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags
+
+import (
+	"fmt"
+	"os"
+)
+
+// windowsServiceCommand holds the four operations HandleWindowsServiceCommand
+// dispatches to. Splitting them out like this, instead of calling
+// InstallWindowsService et al. directly, lets dispatch be unit-tested with
+// fakes on any platform, without touching the real Windows service control
+// manager.
+type windowsServiceCommand struct {
+	install   func(name, displayName, description string, args ...string) error
+	uninstall func(name string) error
+	start     func(name string) error
+	stop      func(name string) error
+}
+
+// dispatch inspects args for the "install", "uninstall", "start" or "stop"
+// subcommand and, if found, invokes the matching field and returns true. If
+// that call fails, the error is printed to stderr and the process exits with
+// status 1. Any remaining args after "install" are passed through to
+// c.install.
+func (c windowsServiceCommand) dispatch(name, displayName, description string, args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	var err error
+	switch args[0] {
+	case "install":
+		err = c.install(name, displayName, description, args[1:]...)
+	case "uninstall":
+		err = c.uninstall(name)
+	case "start":
+		err = c.start(name)
+	case "stop":
+		err = c.stop(name)
+	default:
+		return false
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowsServiceCommandDispatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCall string
+		wantOK   bool
+	}{
+		{name: "install", args: []string{"install", "--foo"}, wantCall: "install", wantOK: true},
+		{name: "uninstall", args: []string{"uninstall"}, wantCall: "uninstall", wantOK: true},
+		{name: "start", args: []string{"start"}, wantCall: "start", wantOK: true},
+		{name: "stop", args: []string{"stop"}, wantCall: "stop", wantOK: true},
+		{name: "unrecognized", args: []string{"version"}, wantCall: "", wantOK: false},
+		{name: "empty", args: nil, wantCall: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called string
+			var gotArgs []string
+			cmd := windowsServiceCommand{
+				install: func(name, displayName, description string, args ...string) error {
+					called = "install"
+					gotArgs = args
+					return nil
+				},
+				uninstall: func(name string) error { called = "uninstall"; return nil },
+				start:     func(name string) error { called = "start"; return nil },
+				stop:      func(name string) error { called = "stop"; return nil },
+			}
+
+			ok := cmd.dispatch("svc", "Service", "desc", tt.args)
+
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantCall, called)
+			if tt.wantCall == "install" {
+				assert.Equal(t, tt.args[1:], gotArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package flags
+
+import "github.com/pkg/errors"
+
+// errWindowsOnly is returned by the Windows service management helpers on
+// every other platform.
+var errWindowsOnly = errors.New("Windows service support is only available when running on Windows")
+
+// SetWindowsServiceName is a no-op on non-Windows platforms.
+func (s *Service) SetWindowsServiceName(name string) {}
+
+// RunAsWindowsService is not meaningful outside of Windows, so it simply
+// falls back to RunAndThen.
+func (s *Service) RunAsWindowsService(shutdown func()) error {
+	s.RunAndThen(shutdown)
+	return nil
+}
+
+// InstallWindowsService is unavailable on non-Windows platforms.
+func InstallWindowsService(name, displayName, description string, args ...string) error {
+	return errWindowsOnly
+}
+
+// UninstallWindowsService is unavailable on non-Windows platforms.
+func UninstallWindowsService(name string) error {
+	return errWindowsOnly
+}
+
+// StartWindowsService is unavailable on non-Windows platforms.
+func StartWindowsService(name string) error {
+	return errWindowsOnly
+}
+
+// StopWindowsService is unavailable on non-Windows platforms.
+func StopWindowsService(name string) error {
+	return errWindowsOnly
+}
+
+// HandleWindowsServiceCommand always returns false on non-Windows platforms,
+// so callers can invoke it unconditionally before falling through to their
+// normal flag parsing.
+func HandleWindowsServiceCommand(name, displayName, description string, args []string) bool {
+	return false
+}
@@ -0,0 +1,285 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+)
+
+// Probe reports an error when the dependency it checks is unhealthy.
+type Probe func(ctx context.Context) error
+
+// CheckOptions configures how a registered sub-check is evaluated.
+type CheckOptions struct {
+	// Interval is how often the probe is invoked. Defaults to 15s.
+	Interval time.Duration
+
+	// Timeout bounds a single probe invocation. Defaults to 5s.
+	Timeout time.Duration
+
+	// FailureThreshold is the number of consecutive failures required
+	// before the sub-check is reported as unhealthy. Defaults to 1.
+	FailureThreshold int
+}
+
+// DefaultCheckOptions returns the CheckOptions used when RegisterCheck is
+// called with a zero value.
+func DefaultCheckOptions() CheckOptions {
+	return CheckOptions{
+		Interval:         15 * time.Second,
+		Timeout:          5 * time.Second,
+		FailureThreshold: 1,
+	}
+}
+
+func (o CheckOptions) withDefaults() CheckOptions {
+	if o.Interval <= 0 {
+		o.Interval = 15 * time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+	if o.FailureThreshold <= 0 {
+		o.FailureThreshold = 1
+	}
+	return o
+}
+
+// checkResult is the JSON representation of a single sub-check, served at
+// /health.
+type checkResult struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// namedCheck tracks the running state of a single registered probe.
+type namedCheck struct {
+	name             string
+	opts             CheckOptions
+	consecutiveFails int
+
+	mu      sync.RWMutex
+	healthy bool
+	lastErr error
+
+	upGauge metrics.Gauge
+}
+
+func (c *namedCheck) snapshot() checkResult {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	r := checkResult{Name: c.name, Healthy: c.healthy}
+	if c.lastErr != nil {
+		r.Error = c.lastErr.Error()
+	}
+	return r
+}
+
+func (c *namedCheck) record(err error) (becameUnhealthy, becameHealthy bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wasHealthy := c.healthy
+	if err == nil {
+		c.consecutiveFails = 0
+		c.lastErr = nil
+		c.healthy = true
+	} else {
+		c.consecutiveFails++
+		c.lastErr = err
+		if c.consecutiveFails >= c.opts.FailureThreshold {
+			c.healthy = false
+		}
+	}
+
+	if c.upGauge != nil {
+		if c.healthy {
+			c.upGauge.Update(1)
+		} else {
+			c.upGauge.Update(0)
+		}
+	}
+
+	return wasHealthy && !c.healthy, !wasHealthy && c.healthy
+}
+
+// Registry runs named probes on their own goroutines and pushes aggregated
+// status transitions onto statusCh, the same channel Service uses for every
+// other health status change.
+type Registry struct {
+	statusCh chan<- Status
+	logger   *zap.Logger
+
+	mu      sync.RWMutex
+	checks  map[string]*namedCheck
+	stopped bool
+}
+
+// NewRegistry creates a Registry that pushes onto statusCh: failing checks
+// push Unavailable, and Ready is pushed once every registered check is
+// passing again.
+func NewRegistry(statusCh chan<- Status, logger *zap.Logger) *Registry {
+	return &Registry{
+		statusCh: statusCh,
+		logger:   logger,
+		checks:   make(map[string]*namedCheck),
+	}
+}
+
+// Stop marks the registry as shutting down: no further status transitions
+// are pushed onto statusCh, even if a probe that was already in flight
+// completes and observes every check passing afterwards. Callers should call
+// Stop before tearing down whatever reads statusCh.
+func (r *Registry) Stop() {
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+}
+
+// Register starts a goroutine that evaluates probe every opts.Interval
+// (after applying defaults) until ctx is done, folding consecutive
+// failures into the aggregated HealthCheck status once FailureThreshold is
+// reached.
+func (r *Registry) Register(ctx context.Context, name string, probe Probe, opts CheckOptions, metricsFactory metrics.Factory) {
+	opts = opts.withDefaults()
+
+	check := &namedCheck{
+		name:    name,
+		opts:    opts,
+		healthy: true,
+	}
+	if metricsFactory != nil {
+		check.upGauge = metricsFactory.Gauge(metrics.Options{
+			Name: "up",
+			Tags: map[string]string{"check": name},
+		})
+		check.upGauge.Update(1)
+	}
+
+	r.mu.Lock()
+	r.checks[name] = check
+	r.mu.Unlock()
+
+	go r.run(ctx, probe, opts, check)
+}
+
+func (r *Registry) run(ctx context.Context, probe Probe, opts CheckOptions, check *namedCheck) {
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	r.evaluate(ctx, probe, opts, check)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.evaluate(ctx, probe, opts, check)
+		}
+	}
+}
+
+func (r *Registry) evaluate(ctx context.Context, probe Probe, opts CheckOptions, check *namedCheck) {
+	probeCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	err := probe(probeCtx)
+	becameUnhealthy, becameHealthy := check.record(err)
+
+	if becameUnhealthy {
+		r.logger.Error("Health check failed", zap.String("check", check.name), zap.Error(err))
+		r.pushStatus(ctx, Unavailable)
+	} else if becameHealthy {
+		r.logger.Info("Health check recovered", zap.String("check", check.name))
+	}
+
+	if becameHealthy && r.allHealthy() {
+		r.pushStatus(ctx, Ready)
+	}
+}
+
+// pushStatus sends status on statusCh, unless Stop has already been called
+// or ctx is done, whichever happens first. The ctx check keeps a send from
+// blocking forever once nothing reads statusCh anymore.
+func (r *Registry) pushStatus(ctx context.Context, status Status) {
+	r.mu.RLock()
+	stopped := r.stopped
+	r.mu.RUnlock()
+	if stopped {
+		return
+	}
+
+	select {
+	case r.statusCh <- status:
+	case <-ctx.Done():
+	}
+}
+
+func (r *Registry) allHealthy() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, c := range r.checks {
+		c.mu.RLock()
+		healthy := c.healthy
+		c.mu.RUnlock()
+		if !healthy {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Registry) snapshot() []checkResult {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := make([]checkResult, 0, len(r.checks))
+	for _, c := range r.checks {
+		results = append(results, c.snapshot())
+	}
+	return results
+}
+
+// Handler returns an http.Handler serving the per-check results as JSON.
+// Appending ?pretty=1 to the request indents the output.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		checks := r.snapshot()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		var (
+			body []byte
+			err  error
+		)
+		if req.URL.Query().Get("pretty") == "1" {
+			body, err = json.MarshalIndent(checks, "", "  ")
+		} else {
+			body, err = json.Marshal(checks)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(body)
+	})
+}
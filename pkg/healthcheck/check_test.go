@@ -0,0 +1,78 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package healthcheck
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckOptionsWithDefaults(t *testing.T) {
+	assert.Equal(t, DefaultCheckOptions(), CheckOptions{}.withDefaults())
+
+	custom := CheckOptions{Interval: time.Second, Timeout: time.Millisecond, FailureThreshold: 3}
+	assert.Equal(t, custom, custom.withDefaults())
+}
+
+func TestNamedCheckRecordThreshold(t *testing.T) {
+	c := &namedCheck{opts: CheckOptions{FailureThreshold: 2}, healthy: true}
+
+	becameUnhealthy, becameHealthy := c.record(errors.New("boom"))
+	assert.False(t, becameUnhealthy, "first failure should not cross the threshold yet")
+	assert.False(t, becameHealthy)
+	assert.True(t, c.snapshot().Healthy)
+
+	becameUnhealthy, becameHealthy = c.record(errors.New("boom again"))
+	assert.True(t, becameUnhealthy, "second consecutive failure should cross the threshold")
+	assert.False(t, becameHealthy)
+	assert.False(t, c.snapshot().Healthy)
+
+	becameUnhealthy, becameHealthy = c.record(nil)
+	assert.False(t, becameUnhealthy)
+	assert.True(t, becameHealthy, "a single success should recover")
+	assert.True(t, c.snapshot().Healthy)
+}
+
+func TestRegistryAllHealthy(t *testing.T) {
+	r := &Registry{checks: map[string]*namedCheck{
+		"a": {name: "a", healthy: true},
+		"b": {name: "b", healthy: true},
+	}}
+	assert.True(t, r.allHealthy())
+
+	r.checks["b"].healthy = false
+	assert.False(t, r.allHealthy())
+}
+
+func TestRegistryHandler(t *testing.T) {
+	r := &Registry{checks: map[string]*namedCheck{
+		"dep": {name: "dep", healthy: false, lastErr: errors.New("down")},
+	}}
+
+	results := r.snapshot()
+	require.Len(t, results, 1)
+	assert.Equal(t, checkResult{Name: "dep", Healthy: false, Error: "down"}, results[0])
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, req)
+	assert.Contains(t, w.Body.String(), `"name":"dep"`)
+}
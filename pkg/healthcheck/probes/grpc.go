@@ -0,0 +1,46 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package probes provides built-in healthcheck.Probe implementations that
+// Service.RegisterCheck callers can use instead of hand-rolling the common
+// cases: a gRPC dependency, an HTTP dependency, or the configured span
+// store.
+package probes
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+)
+
+// NewGRPCProbe returns a healthcheck.Probe that issues a standard
+// grpc.health.v1 Check RPC for service over the given connection, which the
+// caller owns and is responsible for closing.
+func NewGRPCProbe(conn *grpc.ClientConn, service string) healthcheck.Probe {
+	client := grpc_health_v1.NewHealthClient(conn)
+	return func(ctx context.Context) error {
+		resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+		if err != nil {
+			return err
+		}
+		if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+			return fmt.Errorf("grpc health check for %q reported status %s", service, resp.Status)
+		}
+		return nil
+	}
+}
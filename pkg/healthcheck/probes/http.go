@@ -0,0 +1,63 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package probes
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+
+	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+)
+
+// NewHTTPProbe returns a healthcheck.Probe that issues a GET to url and
+// fails unless the response status equals wantStatus and, when bodyRegexp
+// is non-nil, the response body matches it. A nil client defaults to
+// http.DefaultClient.
+func NewHTTPProbe(client *http.Client, url string, wantStatus int, bodyRegexp *regexp.Regexp) healthcheck.Probe {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != wantStatus {
+			return fmt.Errorf("GET %s returned status %d, want %d", url, resp.StatusCode, wantStatus)
+		}
+
+		if bodyRegexp == nil {
+			return nil
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		if !bodyRegexp.Match(body) {
+			return fmt.Errorf("GET %s body did not match %s", url, bodyRegexp.String())
+		}
+		return nil
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package probes
+
+import (
+	"context"
+
+	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+)
+
+// Pinger is implemented by span stores that can report their own liveness,
+// e.g. by round-tripping a trivial query against the backing storage.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// NewStorageProbe returns a healthcheck.Probe that delegates to the
+// configured span store's Ping method.
+func NewStorageProbe(store Pinger) healthcheck.Probe {
+	return func(ctx context.Context) error {
+		return store.Ping(ctx)
+	}
+}